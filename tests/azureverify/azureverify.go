@@ -0,0 +1,130 @@
+// Package azureverify asserts on the real state of Azure resources via the
+// azure-sdk-for-go, catching divergence between Terraform state and what
+// Azure actually provisioned that output-only Terratest assertions miss.
+// It authenticates with the same ARM_CLIENT_ID / ARM_CLIENT_SECRET /
+// ARM_TENANT_ID environment variables used by the Terraform AzureRM
+// provider, not the azidentity default envvar names.
+package azureverify
+
+import (
+    "context"
+    "os"
+    "testing"
+
+    "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+    "github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+    "github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+    "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+    "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+)
+
+// ExpectedVMProperties is what a test expects a VM to look like, compared
+// against the live Azure resource.
+type ExpectedVMProperties struct {
+    VMSize              string
+    OSDiskSKU           string
+    BootDiagnosticsOn   bool
+}
+
+// ExpectedNSGRule is what a test expects a single NSG rule to look like.
+type ExpectedNSGRule struct {
+    SourceAddressPrefix      string
+    DestinationPortRange     string
+    Priority                 int32
+}
+
+// newCredential builds a credential from the same ARM_CLIENT_ID /
+// ARM_CLIENT_SECRET / ARM_TENANT_ID variables Terraform's AzureRM provider
+// reads, rather than azidentity's own AZURE_* envvar names.
+func newCredential(t *testing.T) azcore.TokenCredential {
+    t.Helper()
+
+    tenantID := os.Getenv("ARM_TENANT_ID")
+    clientID := os.Getenv("ARM_CLIENT_ID")
+    clientSecret := os.Getenv("ARM_CLIENT_SECRET")
+    if tenantID == "" || clientID == "" || clientSecret == "" {
+        t.Fatalf("ARM_TENANT_ID, ARM_CLIENT_ID, and ARM_CLIENT_SECRET must be set")
+    }
+
+    cred, err := azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, nil)
+    if err != nil {
+        t.Fatalf("creating Azure credential from ARM_* environment variables: %v", err)
+    }
+    return cred
+}
+
+// AssertVMProperties fetches the VM from Azure and asserts that its size, OS
+// disk SKU, boot diagnostics state, and provisioning state match expected.
+func AssertVMProperties(t *testing.T, subscriptionID, resourceGroup, vmName string, expected ExpectedVMProperties) {
+    t.Helper()
+
+    client, err := armcompute.NewVirtualMachinesClient(subscriptionID, newCredential(t), nil)
+    if err != nil {
+        t.Fatalf("creating VM client: %v", err)
+    }
+
+    resp, err := client.Get(context.Background(), resourceGroup, vmName, &armcompute.VirtualMachinesClientGetOptions{
+        Expand: to.Ptr(armcompute.InstanceViewTypesInstanceView),
+    })
+    if err != nil {
+        t.Fatalf("fetching VM %s/%s: %v", resourceGroup, vmName, err)
+    }
+
+    props := resp.Properties
+    if props == nil {
+        t.Fatalf("VM %s/%s has no properties", resourceGroup, vmName)
+    }
+
+    if props.HardwareProfile == nil || string(*props.HardwareProfile.VMSize) != expected.VMSize {
+        t.Errorf("VM %s: expected size %s, got %v", vmName, expected.VMSize, props.HardwareProfile)
+    }
+
+    if props.StorageProfile == nil || props.StorageProfile.OSDisk == nil ||
+        props.StorageProfile.OSDisk.ManagedDisk == nil ||
+        string(*props.StorageProfile.OSDisk.ManagedDisk.StorageAccountType) != expected.OSDiskSKU {
+        t.Errorf("VM %s: expected OS disk SKU %s, got %v", vmName, expected.OSDiskSKU, props.StorageProfile)
+    }
+
+    bootDiagnosticsOn := props.DiagnosticsProfile != nil &&
+        props.DiagnosticsProfile.BootDiagnostics != nil &&
+        props.DiagnosticsProfile.BootDiagnostics.Enabled != nil &&
+        *props.DiagnosticsProfile.BootDiagnostics.Enabled
+    if bootDiagnosticsOn != expected.BootDiagnosticsOn {
+        t.Errorf("VM %s: expected boot diagnostics enabled=%v, got %v", vmName, expected.BootDiagnosticsOn, bootDiagnosticsOn)
+    }
+
+    if props.ProvisioningState == nil || *props.ProvisioningState != "Succeeded" {
+        t.Errorf("VM %s: expected provisioningState Succeeded, got %v", vmName, props.ProvisioningState)
+    }
+}
+
+// AssertNSGRule fetches the named security rule from the NSG and asserts its
+// source prefix, destination port, and priority match expected.
+func AssertNSGRule(t *testing.T, subscriptionID, resourceGroup, nsgName, ruleName string, expected ExpectedNSGRule) {
+    t.Helper()
+
+    client, err := armnetwork.NewSecurityRulesClient(subscriptionID, newCredential(t), nil)
+    if err != nil {
+        t.Fatalf("creating NSG rules client: %v", err)
+    }
+
+    resp, err := client.Get(context.Background(), resourceGroup, nsgName, ruleName, nil)
+    if err != nil {
+        t.Fatalf("fetching NSG rule %s/%s/%s: %v", resourceGroup, nsgName, ruleName, err)
+    }
+
+    props := resp.Properties
+    if props == nil {
+        t.Fatalf("NSG rule %s has no properties", ruleName)
+    }
+
+    if props.SourceAddressPrefix == nil || *props.SourceAddressPrefix != expected.SourceAddressPrefix {
+        t.Errorf("NSG rule %s: expected source prefix %s, got %v", ruleName, expected.SourceAddressPrefix, props.SourceAddressPrefix)
+    }
+    if props.DestinationPortRange == nil || *props.DestinationPortRange != expected.DestinationPortRange {
+        t.Errorf("NSG rule %s: expected destination port range %s, got %v", ruleName, expected.DestinationPortRange, props.DestinationPortRange)
+    }
+    if props.Priority == nil || *props.Priority != expected.Priority {
+        t.Errorf("NSG rule %s: expected priority %d, got %v", ruleName, expected.Priority, props.Priority)
+    }
+}
@@ -0,0 +1,336 @@
+// Cost estimation helpers for gating Terraform plans on monthly spend.
+// Re-plans to a file so "terraform show -json" reflects the plan's
+// resource_changes, then feeds that JSON to a pluggable pricing backend so
+// CI can reject PRs that inflate cost.
+
+package test
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "os"
+    "os/exec"
+    "strconv"
+    "testing"
+
+    "github.com/gruntwork-io/terratest/modules/terraform"
+    "gopkg.in/yaml.v2"
+)
+
+// CostEstimator prices the resource changes found in a Terraform JSON plan.
+// Implementations may shell out to a CLI (Infracost) or call a pricing API
+// (Azure Retail Prices) directly.
+type CostEstimator interface {
+    // EstimateMonthlyDelta returns the estimated monthly cost delta, in USD,
+    // for the resource changes in the given "terraform show -json" plan file.
+    EstimateMonthlyDelta(planJSONPath string) (*CostBreakdownReport, error)
+}
+
+// CostBreakdownReport is the per-resource cost delta for a single plan.
+type CostBreakdownReport struct {
+    TotalMonthlyDelta float64
+    Resources         []ResourceCost
+}
+
+// ResourceCost is the estimated monthly cost delta for a single resource
+// address in the plan.
+type ResourceCost struct {
+    Address     string
+    ResourceType string
+    MonthlyDelta float64
+}
+
+// Log writes a human-readable per-resource cost table to t.Logf.
+func (r *CostBreakdownReport) Log(t *testing.T) {
+    t.Logf("Cost breakdown (estimated monthly delta: $%.2f)", r.TotalMonthlyDelta)
+    for _, rc := range r.Resources {
+        t.Logf("  %-12s %-50s $%.2f/mo", rc.ResourceType, rc.Address, rc.MonthlyDelta)
+    }
+}
+
+// EnvironmentBudget is a single environment's entry in budgets.yaml.
+type EnvironmentBudget struct {
+    Environment      string  `yaml:"environment"`
+    MaxMonthlyDelta  float64 `yaml:"max_monthly_delta"`
+}
+
+// budgetsFile mirrors the structure of test/budgets.yaml.
+type budgetsFile struct {
+    Budgets []EnvironmentBudget `yaml:"budgets"`
+}
+
+// loadBudget reads budgets.yaml and returns the budget for the named
+// environment.
+func loadBudget(budgetsPath, environment string) (*EnvironmentBudget, error) {
+    data, err := os.ReadFile(budgetsPath)
+    if err != nil {
+        return nil, fmt.Errorf("reading budgets file: %w", err)
+    }
+
+    var bf budgetsFile
+    if err := yaml.Unmarshal(data, &bf); err != nil {
+        return nil, fmt.Errorf("parsing budgets file: %w", err)
+    }
+
+    for _, b := range bf.Budgets {
+        if b.Environment == environment {
+            return &b, nil
+        }
+    }
+    return nil, fmt.Errorf("no budget declared for environment %q in %s", environment, budgetsPath)
+}
+
+// InfracostEstimator shells out to the Infracost CLI to price a plan.
+type InfracostEstimator struct {
+    // BaselinePlanJSONPath, when set, is passed as --compare-to so only the
+    // *change* between this plan and the baseline is counted.
+    BaselinePlanJSONPath string
+}
+
+func (e *InfracostEstimator) EstimateMonthlyDelta(planJSONPath string) (*CostBreakdownReport, error) {
+    args := []string{"breakdown", "--path", planJSONPath, "--format", "json"}
+    if e.BaselinePlanJSONPath != "" {
+        args = append(args, "--compare-to", e.BaselinePlanJSONPath)
+    }
+
+    out, err := exec.Command("infracost", args...).Output()
+    if err != nil {
+        return nil, fmt.Errorf("running infracost: %w", err)
+    }
+
+    var parsed struct {
+        TotalMonthlyCost     string `json:"totalMonthlyCost"`
+        DiffTotalMonthlyCost string `json:"diffTotalMonthlyCost"`
+        Projects             []struct {
+            Breakdown struct {
+                Resources []struct {
+                    Name             string `json:"name"`
+                    ResourceType     string `json:"resourceType"`
+                    MonthlyCost      string `json:"monthlyCost"`
+                } `json:"resources"`
+            } `json:"breakdown"`
+            Diff struct {
+                Resources []struct {
+                    Name             string `json:"name"`
+                    ResourceType     string `json:"resourceType"`
+                    MonthlyCost      string `json:"monthlyCost"`
+                } `json:"resources"`
+            } `json:"diff"`
+        } `json:"projects"`
+    }
+    if err := json.Unmarshal(out, &parsed); err != nil {
+        return nil, fmt.Errorf("parsing infracost output: %w", err)
+    }
+
+    report := &CostBreakdownReport{}
+    // With --compare-to set, totalMonthlyCost is the plan's absolute spend;
+    // diffTotalMonthlyCost is the delta against the baseline, which is what
+    // the budget in budgets.yaml is meant to gate.
+    if e.BaselinePlanJSONPath != "" {
+        report.TotalMonthlyDelta, _ = strconv.ParseFloat(parsed.DiffTotalMonthlyCost, 64)
+    } else {
+        report.TotalMonthlyDelta, _ = strconv.ParseFloat(parsed.TotalMonthlyCost, 64)
+    }
+    for _, p := range parsed.Projects {
+        // Per-resource costs follow the same rule: the diff section holds
+        // the change against the baseline, the breakdown the absolute cost.
+        resources := p.Breakdown.Resources
+        if e.BaselinePlanJSONPath != "" {
+            resources = p.Diff.Resources
+        }
+        for _, r := range resources {
+            cost, _ := strconv.ParseFloat(r.MonthlyCost, 64)
+            report.Resources = append(report.Resources, ResourceCost{
+                Address:      r.Name,
+                ResourceType: r.ResourceType,
+                MonthlyDelta: cost,
+            })
+        }
+    }
+    return report, nil
+}
+
+// AzureRetailPricesEstimator prices plan changes directly against the Azure
+// Retail Prices API (https://prices.azure.com), avoiding a dependency on the
+// Infracost CLI being installed on the runner.
+type AzureRetailPricesEstimator struct {
+    // Region is the Azure region used to look up retail prices, e.g.
+    // "australiaeast".
+    Region string
+}
+
+func (e *AzureRetailPricesEstimator) EstimateMonthlyDelta(planJSONPath string) (*CostBreakdownReport, error) {
+    data, err := os.ReadFile(planJSONPath)
+    if err != nil {
+        return nil, fmt.Errorf("reading plan json: %w", err)
+    }
+
+    var plan struct {
+        ResourceChanges []struct {
+            Address string `json:"address"`
+            Type    string `json:"type"`
+            Change  struct {
+                Actions []string               `json:"actions"`
+                Before  map[string]interface{} `json:"before"`
+                After   map[string]interface{} `json:"after"`
+            } `json:"change"`
+        } `json:"resource_changes"`
+    }
+    if err := json.Unmarshal(data, &plan); err != nil {
+        return nil, fmt.Errorf("parsing plan json: %w", err)
+    }
+
+    report := &CostBreakdownReport{}
+    for _, rc := range plan.ResourceChanges {
+        if isNoOpChange(rc.Change.Actions) {
+            continue
+        }
+        // Price both sides of the change so an in-place update counts only
+        // the difference and a delete counts as a saving.
+        before, err := e.lookupMonthlyPrice(rc.Type, rc.Change.Before)
+        if err != nil {
+            return nil, fmt.Errorf("pricing %s: %w", rc.Address, err)
+        }
+        after, err := e.lookupMonthlyPrice(rc.Type, rc.Change.After)
+        if err != nil {
+            return nil, fmt.Errorf("pricing %s: %w", rc.Address, err)
+        }
+        monthly := after - before
+        report.TotalMonthlyDelta += monthly
+        report.Resources = append(report.Resources, ResourceCost{
+            Address:      rc.Address,
+            ResourceType: rc.Type,
+            MonthlyDelta: monthly,
+        })
+    }
+    return report, nil
+}
+
+// lookupMonthlyPrice queries the Azure Retail Prices API for the SKU implied
+// by the given resource attributes (a change's "before" or "after"). Only a handful of resource types are
+// priced today; unknown types are treated as zero-cost.
+func (e *AzureRetailPricesEstimator) lookupMonthlyPrice(resourceType string, after map[string]interface{}) (float64, error) {
+    switch resourceType {
+    case "azurerm_linux_virtual_machine", "azurerm_windows_virtual_machine":
+        return e.priceVM(after)
+    case "azurerm_managed_disk":
+        return e.priceDisk(after)
+    default:
+        return 0, nil
+    }
+}
+
+func (e *AzureRetailPricesEstimator) priceVM(after map[string]interface{}) (float64, error) {
+    size, _ := after["size"].(string)
+    if size == "" {
+        return 0, nil
+    }
+    return retailHourlyPrice(e.Region, "Virtual Machines", size) * 730, nil
+}
+
+func (e *AzureRetailPricesEstimator) priceDisk(after map[string]interface{}) (float64, error) {
+    skuName, _ := after["storage_account_type"].(string)
+    if skuName == "" {
+        return 0, nil
+    }
+    return retailHourlyPrice(e.Region, "Storage", skuName) * 730, nil
+}
+
+// azureRetailPricesAPI is the Azure Retail Prices API endpoint queried by
+// retailHourlyPrice. See https://learn.microsoft.com/en-us/rest/api/cost-management/retail-prices/azure-retail-prices
+const azureRetailPricesAPI = "https://prices.azure.com/api/retail/prices"
+
+// retailHourlyPrice is a thin wrapper around the Azure Retail Prices API,
+// split out so tests can stub it without hitting the network. It returns the
+// lowest "Consumption" retailPrice matching region/serviceName/skuName, or 0
+// if nothing matches.
+var retailHourlyPrice = func(region, serviceName, skuName string) float64 {
+    filter := fmt.Sprintf(
+        "armRegionName eq '%s' and serviceName eq '%s' and skuName eq '%s' and priceType eq 'Consumption'",
+        region, serviceName, skuName,
+    )
+    reqURL := azureRetailPricesAPI + "?$filter=" + url.QueryEscape(filter)
+
+    resp, err := http.Get(reqURL)
+    if err != nil {
+        return 0
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return 0
+    }
+
+    var parsed struct {
+        Items []struct {
+            RetailPrice float64 `json:"retailPrice"`
+        } `json:"Items"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+        return 0
+    }
+
+    lowest := 0.0
+    for i, item := range parsed.Items {
+        if i == 0 || item.RetailPrice < lowest {
+            lowest = item.RetailPrice
+        }
+    }
+    return lowest
+}
+
+func isNoOpChange(actions []string) bool {
+    if len(actions) == 0 {
+        return true
+    }
+    return len(actions) == 1 && actions[0] == "no-op"
+}
+
+// AssertPlanCostWithinBudget plans terraformOptions to a temporary plan file
+// (setting terraformOptions.PlanFilePath), runs terraform show -json against
+// it, estimates the monthly cost delta with estimator, and
+// fails t if it exceeds the budget declared for environment in budgetsPath.
+func AssertPlanCostWithinBudget(t *testing.T, terraformOptions *terraform.Options, environment, budgetsPath string, estimator CostEstimator) *CostBreakdownReport {
+    t.Helper()
+
+    budget, err := loadBudget(budgetsPath, environment)
+    if err != nil {
+        t.Fatalf("loading budget: %v", err)
+    }
+
+    planOutFile, err := os.CreateTemp("", "tfplan-*.tfplan")
+    if err != nil {
+        t.Fatalf("creating temp plan file: %v", err)
+    }
+    planOutFile.Close()
+    defer os.Remove(planOutFile.Name())
+
+    // Persist the plan to disk so "show -json" below reflects the plan's
+    // resource_changes, not the (empty, since nothing is applied) state.
+    terraformOptions.PlanFilePath = planOutFile.Name()
+    terraform.InitAndPlan(t, terraformOptions)
+    planJSON := terraform.RunTerraformCommand(t, terraformOptions, "show", "-json", terraformOptions.PlanFilePath)
+
+    planFile, err := os.CreateTemp("", "tfplan-*.json")
+    if err != nil {
+        t.Fatalf("creating temp plan file: %v", err)
+    }
+    defer os.Remove(planFile.Name())
+    if _, err := planFile.WriteString(planJSON); err != nil {
+        t.Fatalf("writing temp plan file: %v", err)
+    }
+    planFile.Close()
+
+    report, err := estimator.EstimateMonthlyDelta(planFile.Name())
+    if err != nil {
+        t.Fatalf("estimating plan cost: %v", err)
+    }
+    report.Log(t)
+
+    if report.TotalMonthlyDelta > budget.MaxMonthlyDelta {
+        t.Errorf("plan's estimated monthly cost delta $%.2f exceeds %s budget of $%.2f",
+            report.TotalMonthlyDelta, environment, budget.MaxMonthlyDelta)
+    }
+    return report
+}
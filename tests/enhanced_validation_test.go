@@ -7,6 +7,8 @@ import (
     "testing"
     "github.com/gruntwork-io/terratest/modules/terraform"
     "github.com/stretchr/testify/assert"
+
+    "github.com/ManojNair/IaCBestPractices/tests/fixture"
 )
 
 func TestTerraformValidationWithoutCredentials(t *testing.T) {
@@ -48,13 +50,13 @@ func TestIndividualModulesValidation(t *testing.T) {
 
     modules := []struct {
         name        string
-        path        string
+        source      fixture.Fixture
         vars        map[string]interface{}
         description string
     }{
         {
-            name: "VNetModule",
-            path: "../modules/networking/vnet",
+            name:   "VNetModule",
+            source: fixture.RemoteDir{Path: "../modules/networking/vnet"},
             vars: map[string]interface{}{
                 "workload":            "test",
                 "environment":         "test",
@@ -69,8 +71,8 @@ func TestIndividualModulesValidation(t *testing.T) {
             description: "Virtual Network module should validate correctly",
         },
         {
-            name: "NSGModule",
-            path: "../modules/networking/nsg",
+            name:   "NSGModule",
+            source: fixture.RemoteDir{Path: "../modules/networking/nsg"},
             vars: map[string]interface{}{
                 "name_prefix":         "test",
                 "environment":         "test",
@@ -84,8 +86,25 @@ func TestIndividualModulesValidation(t *testing.T) {
             description: "Network Security Group module should validate correctly",
         },
         {
-            name: "VMModule",
-            path: "../modules/compute/vm",
+            name:   "NSGModuleEmptyAllowedSSHIPs",
+            source: fixture.InlineHCL{Body: `
+module "nsg" {
+  source              = "../modules/networking/nsg"
+  name_prefix         = "test"
+  environment         = "test"
+  location            = "Australia East"
+  resource_group_name = "rg-test"
+  allowed_ssh_ips     = []
+  allow_http          = true
+  allow_https         = true
+  tags                = { Environment = "test" }
+}
+`},
+            description: "NSG module should validate with an empty allowed_ssh_ips",
+        },
+        {
+            name:   "VMModule",
+            source: fixture.RemoteDir{Path: "../modules/compute/vm"},
             vars: map[string]interface{}{
                 "workload":            "test",
                 "environment":         "test",
@@ -110,17 +129,18 @@ func TestIndividualModulesValidation(t *testing.T) {
         t.Run(module.name, func(t *testing.T) {
             t.Parallel()
 
+            dir := module.source.Dir(t)
             terraformOptions := &terraform.Options{
-                TerraformDir: module.path,
+                TerraformDir: dir,
                 Vars:         module.vars,
             }
 
             // Initialize and validate the module (syntax only)
             terraform.Init(t, terraformOptions)
-            
+
             // For syntax validation, we just need to run validate without vars
-            terraform.RunTerraformCommand(t, &terraform.Options{TerraformDir: module.path}, "validate")
-            
+            terraform.RunTerraformCommand(t, &terraform.Options{TerraformDir: dir}, "validate")
+
             t.Logf("✅ %s", module.description)
         })
     }
@@ -169,8 +189,11 @@ func TestTerraformPlanGeneration(t *testing.T) {
             // Verify that resources will be created
             resourceCount := terraform.GetResourceCount(t, planStruct)
             assert.Greater(t, resourceCount.Add, 0, "Should plan to create at least one resource")
-            
+
             t.Logf("✅ Plan generated successfully with %d resources to add", resourceCount.Add)
+
+            // Gate the plan on this environment's cost budget
+            AssertPlanCostWithinBudget(t, terraformOptions, "dev", "budgets.yaml", &AzureRetailPricesEstimator{Region: "australiaeast"})
         })
     }
 }
@@ -0,0 +1,84 @@
+// Package fixture abstracts where a Terratest module under test comes from:
+// an existing directory under modules/, or HCL synthesized on the fly. This
+// lets a contributor add a regression test for a single edge case (e.g. an
+// NSG with an empty allowed_ssh_ips) without adding a new directory under
+// modules/.
+package fixture
+
+import (
+    "os"
+    "path/filepath"
+    "regexp"
+    "testing"
+)
+
+// Fixture resolves to a directory containing the Terraform configuration a
+// test should run against.
+type Fixture interface {
+    // Dir returns the TerraformDir to pass to terraform.Options, writing any
+    // files it needs to first.
+    Dir(t *testing.T) string
+}
+
+// RemoteDir is today's behavior: a path to an existing directory, typically
+// one of the hardcoded "../modules/..." paths.
+type RemoteDir struct {
+    Path string
+}
+
+func (f RemoteDir) Dir(t *testing.T) string {
+    t.Helper()
+    return f.Path
+}
+
+// InlineHCL synthesizes a temporary module from Body (written as main.tf)
+// plus any additional Files, so a single edge case can be covered without a
+// new directory under modules/.
+type InlineHCL struct {
+    // Body is written to main.tf in the temp dir.
+    Body string
+    // Files maps additional file names (e.g. "variables.tf") to contents.
+    Files map[string]string
+}
+
+// relativeSourceRE matches a module "source" attribute pointing at a
+// relative path, e.g. `source = "../modules/networking/nsg"`.
+var relativeSourceRE = regexp.MustCompile(`(?m)^(\s*source\s*=\s*)"(\.\./[^"]*)"`)
+
+// resolveRelativeSources rewrites relative module "source" paths in body
+// against the test binary's working directory (the package directory, e.g.
+// tests/), so modules written like RemoteDir{Path: "../modules/..."} keep
+// resolving correctly once body is written into a t.TempDir() elsewhere.
+func resolveRelativeSources(body string) (string, error) {
+    wd, err := os.Getwd()
+    if err != nil {
+        return "", err
+    }
+    return relativeSourceRE.ReplaceAllStringFunc(body, func(match string) string {
+        parts := relativeSourceRE.FindStringSubmatch(match)
+        return parts[1] + `"` + filepath.Join(wd, parts[2]) + `"`
+    }), nil
+}
+
+func (f InlineHCL) Dir(t *testing.T) string {
+    t.Helper()
+
+    body, err := resolveRelativeSources(f.Body)
+    if err != nil {
+        t.Fatalf("resolving relative module sources: %v", err)
+    }
+
+    dir := t.TempDir()
+
+    if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(body), 0o644); err != nil {
+        t.Fatalf("writing main.tf: %v", err)
+    }
+
+    for name, contents := range f.Files {
+        if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+            t.Fatalf("writing %s: %v", name, err)
+        }
+    }
+
+    return dir
+}
@@ -0,0 +1,141 @@
+// Package matrix fans a single Terratest func out over a set of
+// (environment, region, workspace) tuples, each in its own Terraform
+// workspace against the same TerraformDir, replacing the ad-hoc duplication
+// between per-environment test functions.
+package matrix
+
+import (
+    "fmt"
+    "sync"
+    "testing"
+
+    "github.com/gruntwork-io/terratest/modules/random"
+    "github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// Tuple is a single cell of the matrix: one workspace to create, apply the
+// given TestFunc against, and tear down.
+type Tuple struct {
+    Environment string
+    Region      string
+    Workspace   string
+    // TerraformDir overrides Config.TerraformDir for this tuple only. Tuples
+    // that share a TerraformDir are still safe to run concurrently, but each
+    // tuple should normally point at its own environment directory (e.g.
+    // environments/dev, environments/staging).
+    TerraformDir string
+    // Vars overrides terraform vars for this tuple only, merged over Config.Vars.
+    Vars map[string]interface{}
+}
+
+// TestFunc is run once per tuple, in its own goroutine, against a
+// terraform.Options scoped to that tuple's workspace.
+type TestFunc func(t *testing.T, terraformOptions *terraform.Options)
+
+// Config describes the matrix run shared across all tuples.
+type Config struct {
+    // TerraformDir is the Terraform configuration used by any tuple that
+    // doesn't set its own TerraformDir.
+    TerraformDir string
+    // Vars are the base variables passed to every tuple, before Tuple.Vars
+    // overrides are merged in.
+    Vars map[string]interface{}
+    // WorkspaceNamePrefix is prepended to a random.UniqueId() suffix to
+    // isolate concurrent runs. Defaults to "matrix" if empty.
+    WorkspaceNamePrefix string
+    // MaxConcurrency bounds how many tuples run at once, to stay within
+    // Azure subscription quota. Defaults to len(tuples) (fully parallel) if
+    // zero.
+    MaxConcurrency int
+}
+
+// dirLocks serializes the Terraform workspace admin commands (new/select/
+// delete) that mutate the on-disk "current workspace" pointer for a given
+// TerraformDir, so tuples that happen to share a directory don't race each
+// other. Tuples pointed at distinct directories never contend.
+var dirLocks sync.Map // map[string]*sync.Mutex
+
+func lockFor(dir string) *sync.Mutex {
+    mu, _ := dirLocks.LoadOrStore(dir, &sync.Mutex{})
+    return mu.(*sync.Mutex)
+}
+
+// Run creates a Terraform workspace per tuple, runs fn against it, and tears
+// the workspace down afterward, bounding concurrency to cfg.MaxConcurrency.
+func Run(t *testing.T, cfg Config, tuples []Tuple, fn TestFunc) {
+    t.Helper()
+
+    prefix := cfg.WorkspaceNamePrefix
+    if prefix == "" {
+        prefix = "matrix"
+    }
+
+    maxConcurrency := cfg.MaxConcurrency
+    if maxConcurrency <= 0 {
+        maxConcurrency = len(tuples)
+    }
+    sem := make(chan struct{}, maxConcurrency)
+
+    var wg sync.WaitGroup
+    for _, tuple := range tuples {
+        tuple := tuple
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            sem <- struct{}{}
+            defer func() { <-sem }()
+
+            t.Run(fmt.Sprintf("%s-%s", tuple.Environment, tuple.Region), func(t *testing.T) {
+                runTuple(t, cfg, prefix, tuple, fn)
+            })
+        }()
+    }
+    wg.Wait()
+}
+
+func runTuple(t *testing.T, cfg Config, prefix string, tuple Tuple, fn TestFunc) {
+    dir := tuple.TerraformDir
+    if dir == "" {
+        dir = cfg.TerraformDir
+    }
+    vars := mergeVars(cfg.Vars, tuple.Vars)
+    workspaceName := fmt.Sprintf("%s-%s-%s", prefix, tuple.Workspace, random.UniqueId())
+
+    // TF_WORKSPACE pins every Terraform invocation below to this tuple's
+    // workspace regardless of what "terraform workspace select" last wrote
+    // to dir's on-disk environment file, which is what keeps concurrent
+    // tuples sharing a directory from stepping on each other's state.
+    terraformOptions := &terraform.Options{
+        TerraformDir: dir,
+        Vars:         vars,
+        EnvVars:      map[string]string{"TF_WORKSPACE": workspaceName},
+    }
+
+    mu := lockFor(dir)
+    mu.Lock()
+    terraform.Init(t, &terraform.Options{TerraformDir: dir})
+    terraform.RunTerraformCommand(t, &terraform.Options{TerraformDir: dir}, "workspace", "new", workspaceName)
+    mu.Unlock()
+
+    defer func() {
+        mu.Lock()
+        defer mu.Unlock()
+        // TF_WORKSPACE must not point at workspaceName here: Terraform
+        // refuses to delete the currently selected workspace.
+        terraform.RunTerraformCommand(t, &terraform.Options{TerraformDir: dir}, "workspace", "select", "default")
+        terraform.RunTerraformCommand(t, &terraform.Options{TerraformDir: dir}, "workspace", "delete", workspaceName)
+    }()
+
+    fn(t, terraformOptions)
+}
+
+func mergeVars(base, overrides map[string]interface{}) map[string]interface{} {
+    merged := make(map[string]interface{}, len(base)+len(overrides))
+    for k, v := range base {
+        merged[k] = v
+    }
+    for k, v := range overrides {
+        merged[k] = v
+    }
+    return merged
+}
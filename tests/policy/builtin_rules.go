@@ -0,0 +1,147 @@
+package policy
+
+import (
+    "fmt"
+    "strings"
+)
+
+// DefaultBuiltinRules returns the starter rule set matching the policies
+// shipped as Rego under rules/: no public SSH, Premium_LRS-only managed
+// disks, and mandatory Environment/Owner tags.
+func DefaultBuiltinRules() []Rule {
+    return []Rule{
+        NoPublicSSHRule(),
+        PremiumDiskOnlyRule(),
+        MandatoryTagsRule("Environment", "Owner"),
+    }
+}
+
+// NoPublicSSHRule flags NSG rules that allow inbound TCP/22 from 0.0.0.0/0
+// (or "*"), whether declared as a standalone azurerm_network_security_rule
+// or as an inline security_rule block on an azurerm_network_security_group.
+func NoPublicSSHRule() Rule {
+    return Rule{
+        Name: "no-public-ssh",
+        Check: func(resourceChanges []map[string]interface{}) []Violation {
+            var violations []Violation
+            for _, rc := range resourceChanges {
+                switch rc["type"] {
+                case "azurerm_network_security_rule":
+                    after, _ := after(rc)
+                    if isOpenSSHRule(after) {
+                        violations = append(violations, Violation{
+                            Resource: fmt.Sprintf("%v", rc["address"]),
+                            Message:  "NSG rule must not allow 0.0.0.0/0 on port 22",
+                        })
+                    }
+                case "azurerm_network_security_group":
+                    after, _ := after(rc)
+                    rules, _ := after["security_rule"].([]interface{})
+                    for _, r := range rules {
+                        rule, _ := r.(map[string]interface{})
+                        if isOpenSSHRule(rule) {
+                            violations = append(violations, Violation{
+                                Resource: fmt.Sprintf("%v.security_rule[%v]", rc["address"], rule["name"]),
+                                Message:  "NSG rule must not allow 0.0.0.0/0 on port 22",
+                            })
+                        }
+                    }
+                }
+            }
+            return violations
+        },
+    }
+}
+
+// isOpenSSHRule reports whether an NSG rule's attributes (from either a
+// standalone azurerm_network_security_rule or an inline security_rule
+// block) allow inbound TCP/22 from anywhere.
+func isOpenSSHRule(rule map[string]interface{}) bool {
+    if rule == nil {
+        return false
+    }
+    access, _ := rule["access"].(string)
+    direction, _ := rule["direction"].(string)
+    protocol, _ := rule["protocol"].(string)
+    if !strings.EqualFold(access, "Allow") || !strings.EqualFold(direction, "Inbound") {
+        return false
+    }
+    if !strings.EqualFold(protocol, "Tcp") && protocol != "*" {
+        return false
+    }
+    return portMatches(rule["destination_port_range"], "22") && isOpenToInternet(rule["source_address_prefix"])
+}
+
+// PremiumDiskOnlyRule flags VMs whose OS disk is not Premium_LRS.
+func PremiumDiskOnlyRule() Rule {
+    return Rule{
+        Name: "premium-disk-only",
+        Check: func(resourceChanges []map[string]interface{}) []Violation {
+            var violations []Violation
+            for _, rc := range resourceChanges {
+                typ, _ := rc["type"].(string)
+                if typ != "azurerm_linux_virtual_machine" && typ != "azurerm_windows_virtual_machine" {
+                    continue
+                }
+                after, _ := after(rc)
+                osDisk, _ := after["os_disk"].([]interface{})
+                for _, d := range osDisk {
+                    disk, _ := d.(map[string]interface{})
+                    if sku, _ := disk["storage_account_type"].(string); sku != "" && sku != "Premium_LRS" {
+                        violations = append(violations, Violation{
+                            Resource: fmt.Sprintf("%v", rc["address"]),
+                            Message:  fmt.Sprintf("VM OS disk must use Premium_LRS, got %s", sku),
+                        })
+                    }
+                }
+            }
+            return violations
+        },
+    }
+}
+
+// MandatoryTagsRule flags any resource whose "tags" attribute is missing one
+// of the required keys.
+func MandatoryTagsRule(required ...string) Rule {
+    return Rule{
+        Name: "mandatory-tags",
+        Check: func(resourceChanges []map[string]interface{}) []Violation {
+            var violations []Violation
+            for _, rc := range resourceChanges {
+                after, ok := after(rc)
+                if !ok {
+                    continue
+                }
+                tags, _ := after["tags"].(map[string]interface{})
+                for _, key := range required {
+                    if _, present := tags[key]; !present {
+                        violations = append(violations, Violation{
+                            Resource: fmt.Sprintf("%v", rc["address"]),
+                            Message:  fmt.Sprintf("resource is missing required tag %q", key),
+                        })
+                    }
+                }
+            }
+            return violations
+        },
+    }
+}
+
+func after(rc map[string]interface{}) (map[string]interface{}, bool) {
+    change, ok := rc["change"].(map[string]interface{})
+    if !ok {
+        return nil, false
+    }
+    after, ok := change["after"].(map[string]interface{})
+    return after, ok
+}
+
+func portMatches(destinationPortRange interface{}, port string) bool {
+    p, _ := destinationPortRange.(string)
+    return p == port || p == "*"
+}
+
+func isOpenToInternet(sourceAddressPrefix interface{}) bool {
+    p, _ := sourceAddressPrefix.(string)
+    return p == "0.0.0.0/0" || p == "*" || p == "Internet"
+}
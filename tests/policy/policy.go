@@ -0,0 +1,159 @@
+// Package policy evaluates compliance rules against a Terraform JSON plan so
+// the Terratest suite can fail a PR for violations such as an over-permissive
+// NSG rule or a missing mandatory tag, without needing real Azure credentials.
+package policy
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+
+    "github.com/open-policy-agent/opa/rego"
+)
+
+// Violation is a single rule failure found in a plan.
+//
+// The Terraform plan JSON that engines evaluate ("terraform show -json")
+// carries no file/line positions for resource_changes, so violations can
+// only be attributed to a resource address, not a source line.
+type Violation struct {
+    Rule     string
+    Resource string
+    Message  string
+}
+
+// PolicyEngine evaluates a set of compliance rules against a Terraform JSON
+// plan (the output of "terraform show -json").
+type PolicyEngine interface {
+    // Evaluate returns every Violation found in the plan at planJSONPath.
+    Evaluate(planJSONPath string) ([]Violation, error)
+}
+
+// RegoEngine evaluates .rego policies under RulesDir using OPA's rego
+// package. Each policy is expected to define a "deny" rule that produces a
+// set of objects with "resource" and "message" fields.
+type RegoEngine struct {
+    RulesDir string
+}
+
+func NewRegoEngine(rulesDir string) *RegoEngine {
+    return &RegoEngine{RulesDir: rulesDir}
+}
+
+func (e *RegoEngine) Evaluate(planJSONPath string) ([]Violation, error) {
+    planInput, err := loadPlanInput(planJSONPath)
+    if err != nil {
+        return nil, err
+    }
+
+    regoFiles, err := filepath.Glob(filepath.Join(e.RulesDir, "*.rego"))
+    if err != nil {
+        return nil, fmt.Errorf("globbing rego rules: %w", err)
+    }
+
+    var violations []Violation
+    ctx := context.Background()
+    for _, f := range regoFiles {
+        r := rego.New(
+            rego.Query("data.iacbestpractices.deny"),
+            rego.Load([]string{f}, nil),
+            rego.Input(planInput),
+        )
+        rs, err := r.Eval(ctx)
+        if err != nil {
+            return nil, fmt.Errorf("evaluating %s: %w", f, err)
+        }
+        for _, result := range rs {
+            for _, expr := range result.Expressions {
+                denies, ok := expr.Value.([]interface{})
+                if !ok {
+                    continue
+                }
+                for _, d := range denies {
+                    m, _ := d.(map[string]interface{})
+                    violations = append(violations, Violation{
+                        Rule:     filepath.Base(f),
+                        Resource: fmt.Sprintf("%v", m["resource"]),
+                        Message:  fmt.Sprintf("%v", m["message"]),
+                    })
+                }
+            }
+        }
+    }
+    return violations, nil
+}
+
+// Rule is a single built-in rule for BuiltinEngine. Check receives the
+// decoded "resource_changes" entries from the plan and returns one
+// Violation per offending resource.
+type Rule struct {
+    Name  string
+    Check func(resourceChanges []map[string]interface{}) []Violation
+}
+
+// BuiltinEngine is a lightweight alternative to RegoEngine for rules that
+// don't warrant a standalone Rego policy. Rules run in order and their
+// violations are concatenated.
+type BuiltinEngine struct {
+    Rules []Rule
+}
+
+func NewBuiltinEngine(rules ...Rule) *BuiltinEngine {
+    return &BuiltinEngine{Rules: rules}
+}
+
+func (e *BuiltinEngine) Evaluate(planJSONPath string) ([]Violation, error) {
+    planInput, err := loadPlanInput(planJSONPath)
+    if err != nil {
+        return nil, err
+    }
+
+    resourceChanges := normalizeResourceChanges(planInput)
+
+    var violations []Violation
+    for _, rule := range e.Rules {
+        for _, v := range rule.Check(resourceChanges) {
+            v.Rule = rule.Name
+            violations = append(violations, v)
+        }
+    }
+    return violations, nil
+}
+
+// loadPlanInput reads the JSON plan as-is, with resource_changes left as
+// []interface{}. rego.Input only knows how to convert []interface{} and
+// map[string]interface{} (via ast.InterfaceToValue), so RegoEngine must get
+// this unnormalized shape; BuiltinEngine normalizes its own copy via
+// normalizeResourceChanges for easier rule authoring.
+func loadPlanInput(planJSONPath string) (map[string]interface{}, error) {
+    data, err := os.ReadFile(planJSONPath)
+    if err != nil {
+        return nil, fmt.Errorf("reading plan json: %w", err)
+    }
+
+    var raw map[string]interface{}
+    if err := json.Unmarshal(data, &raw); err != nil {
+        return nil, fmt.Errorf("parsing plan json: %w", err)
+    }
+    return raw, nil
+}
+
+// normalizeResourceChanges extracts planInput's resource_changes as
+// []map[string]interface{}, the shape BuiltinEngine's rules are written
+// against.
+func normalizeResourceChanges(planInput map[string]interface{}) []map[string]interface{} {
+    rc, ok := planInput["resource_changes"].([]interface{})
+    if !ok {
+        return nil
+    }
+
+    normalized := make([]map[string]interface{}, 0, len(rc))
+    for _, item := range rc {
+        if m, ok := item.(map[string]interface{}); ok {
+            normalized = append(normalized, m)
+        }
+    }
+    return normalized
+}
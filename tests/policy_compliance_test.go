@@ -0,0 +1,153 @@
+// Policy-as-code compliance checks, run against the JSON plan of the NSG and
+// VM modules from TestIndividualModulesValidation, plus one non-compliant
+// variant of each. The VNet module and the inline NSG fixture from that test
+// are not planned here.
+
+package test
+
+import (
+    "os"
+    "testing"
+
+    "github.com/gruntwork-io/terratest/modules/terraform"
+    "github.com/stretchr/testify/assert"
+
+    "github.com/ManojNair/IaCBestPractices/tests/policy"
+)
+
+func TestPolicyCompliance(t *testing.T) {
+    t.Parallel()
+
+    modules := []struct {
+        name string
+        path string
+        vars map[string]interface{}
+        // expectViolations marks a negative fixture that should trip a
+        // rule, proving the engines actually detect non-compliance and
+        // not just pass vacuously on already-compliant input.
+        expectViolations bool
+    }{
+        {
+            name: "NSGModule",
+            path: "../modules/networking/nsg",
+            vars: map[string]interface{}{
+                "name_prefix":         "test",
+                "environment":         "test",
+                "location":           "Australia East",
+                "resource_group_name": "rg-test",
+                "allowed_ssh_ips":    []string{"10.0.0.0/8"},
+                "allow_http":         true,
+                "allow_https":        true,
+                "tags":               map[string]string{"Environment": "test", "Owner": "platform-team"},
+            },
+        },
+        {
+            name: "NSGModuleOpenSSH",
+            path: "../modules/networking/nsg",
+            vars: map[string]interface{}{
+                "name_prefix":         "test",
+                "environment":         "test",
+                "location":           "Australia East",
+                "resource_group_name": "rg-test",
+                "allowed_ssh_ips":    []string{"0.0.0.0/0"},
+                "allow_http":         true,
+                "allow_https":        true,
+                "tags":               map[string]string{"Environment": "test", "Owner": "platform-team"},
+            },
+            expectViolations: true,
+        },
+        {
+            name: "VMModule",
+            path: "../modules/compute/vm",
+            vars: map[string]interface{}{
+                "workload":            "test",
+                "environment":         "test",
+                "location":           "Australia East",
+                "location_short":     "aue",
+                "instance":           1,
+                "resource_group_name": "rg-test",
+                "subnet_id":          "/subscriptions/test/resourceGroups/test/providers/Microsoft.Network/virtualNetworks/test/subnets/test",
+                "admin_username":     "testuser",
+                "ssh_public_key":     "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABgQC7... test@example.com",
+                "enable_public_ip":   true,
+                "vm_size":            "Standard_B2s",
+                "os_disk_type":       "Premium_LRS",
+                "common_tags":        map[string]string{"Environment": "test", "Owner": "platform-team"},
+            },
+        },
+        {
+            name: "VMModuleNonPremiumDisk",
+            path: "../modules/compute/vm",
+            vars: map[string]interface{}{
+                "workload":            "test",
+                "environment":         "test",
+                "location":           "Australia East",
+                "location_short":     "aue",
+                "instance":           1,
+                "resource_group_name": "rg-test",
+                "subnet_id":          "/subscriptions/test/resourceGroups/test/providers/Microsoft.Network/virtualNetworks/test/subnets/test",
+                "admin_username":     "testuser",
+                "ssh_public_key":     "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABgQC7... test@example.com",
+                "enable_public_ip":   true,
+                "vm_size":            "Standard_B2s",
+                "os_disk_type":       "Standard_LRS",
+                "common_tags":        map[string]string{"Environment": "test", "Owner": "platform-team"},
+            },
+            expectViolations: true,
+        },
+    }
+
+    regoEngine := policy.NewRegoEngine("policy/rules")
+    builtinEngine := policy.NewBuiltinEngine(policy.DefaultBuiltinRules()...)
+
+    for _, module := range modules {
+        module := module
+        t.Run(module.name, func(t *testing.T) {
+            t.Parallel()
+
+            terraformOptions := &terraform.Options{
+                TerraformDir: module.path,
+                Vars:         module.vars,
+            }
+
+            planOutFile, err := os.CreateTemp("", "policy-plan-*.tfplan")
+            if err != nil {
+                t.Fatalf("creating temp plan file: %v", err)
+            }
+            planOutFile.Close()
+            defer os.Remove(planOutFile.Name())
+
+            // Persist the plan to disk so "show -json" below reflects the
+            // plan's resource_changes, not the (empty, since nothing is
+            // applied) current state.
+            terraformOptions.PlanFilePath = planOutFile.Name()
+            terraform.InitAndPlan(t, terraformOptions)
+            planJSON := terraform.RunTerraformCommand(t, terraformOptions, "show", "-json", terraformOptions.PlanFilePath)
+
+            planFile, err := os.CreateTemp("", "policy-plan-*.json")
+            if err != nil {
+                t.Fatalf("creating temp plan file: %v", err)
+            }
+            defer os.Remove(planFile.Name())
+            if _, err := planFile.WriteString(planJSON); err != nil {
+                t.Fatalf("writing temp plan file: %v", err)
+            }
+            planFile.Close()
+
+            for _, engine := range []policy.PolicyEngine{regoEngine, builtinEngine} {
+                violations, err := engine.Evaluate(planFile.Name())
+                if err != nil {
+                    t.Fatalf("evaluating policy: %v", err)
+                }
+
+                if module.expectViolations {
+                    assert.NotEmptyf(t, violations, "%T should have flagged %s as non-compliant", engine, module.name)
+                    continue
+                }
+                for _, v := range violations {
+                    t.Errorf("[%s] %s: %s", v.Rule, v.Resource, v.Message)
+                }
+            }
+        })
+    }
+}